@@ -0,0 +1,38 @@
+package lock
+
+import (
+	"context"
+	"time"
+)
+
+// RedisClient is the minimal surface New and NewSemaphore need from a Redis
+// driver. Implementing it lets callers plug in a redis.ClusterClient,
+// redis.UniversalClient (Sentinel included), or an entirely different
+// client such as rueidis, instead of being hard-coded to a single
+// *redis.Client. See the goredis and rueidis subpackages for ready-made
+// adapters.
+type RedisClient interface {
+	// SetNX sets key to val with the given TTL only if key doesn't already
+	// exist, reporting whether it did so.
+	SetNX(ctx context.Context, key, val string, ttl time.Duration) (bool, error)
+	// Eval runs a Lua script against the node, returning its raw reply.
+	// Scripts in this package only ever return integers, so callers
+	// normalize the result with toInt64.
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+	// PTTL returns the remaining time to live for key, or a non-positive
+	// duration if key doesn't exist or has no TTL.
+	PTTL(ctx context.Context, key string) (time.Duration, error)
+}
+
+// toInt64 normalizes the integer reply returned by RedisClient.Eval, since
+// different drivers decode EVAL's integer replies to different Go types.
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	default:
+		return 0
+	}
+}
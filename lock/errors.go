@@ -5,5 +5,7 @@ import "errors"
 var (
 	ErrUnableToAcquireLock       = errors.New("unable to acquire lock")
 	ErrUnableToReleaseLock       = errors.New("unable to release lock")
+	ErrUnableToRefreshLock       = errors.New("unable to refresh lock")
+	ErrLockNotHeld               = errors.New("lock not held by this client")
 	ErrContextWithDeadlineNeeded = errors.New("context with deadline needed,Refer more https://tinyurl.com/58ccxyey")
 )
@@ -0,0 +1,164 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// fakeClient is a minimal in-memory RedisClient used to exercise Acquire
+// and Release without a real Redis node. It understands releaseScript,
+// refreshScript, and the semaphore scripts by comparing against the exact
+// Lua strings this package evals, since it has no Lua interpreter of its
+// own.
+type fakeClient struct {
+	mu     sync.Mutex
+	values map[string]string
+	// sets holds the sorted-set member->score state the semaphore scripts
+	// operate on, keyed by semaphore key then holder token.
+	sets  map[string]map[string]int64
+	delay time.Duration
+
+	// refreshCalls/renewCalls count how many times this node has evaled
+	// refreshScript/semaphoreRenewScript, so tests can confirm a watchdog
+	// actually fired instead of just that the fake never expires anything.
+	// forceRefreshFail/forceRenewFail make those scripts report failure, to
+	// exercise the watchdog's Lost() path.
+	refreshCalls     int
+	renewCalls       int
+	forceRefreshFail bool
+	forceRenewFail   bool
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		values: map[string]string{},
+		sets:   map[string]map[string]int64{},
+	}
+}
+
+func (f *fakeClient) SetNX(ctx context.Context, key, val string, ttl time.Duration) (bool, error) {
+	if f.delay > 0 {
+		time.Sleep(f.delay)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.values[key]; ok {
+		return false, nil
+	}
+	f.values[key] = val
+	return true, nil
+}
+
+func (f *fakeClient) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := keys[0]
+	token, _ := args[0].(string)
+
+	switch script {
+	case releaseScript:
+		if f.values[key] == token {
+			delete(f.values, key)
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case refreshScript:
+		f.refreshCalls++
+		if f.forceRefreshFail {
+			return int64(0), nil
+		}
+		if f.values[key] == token {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case semaphoreAcquireScript:
+		now, _ := args[0].(int64)
+		ttlMs, _ := args[1].(int64)
+		n, _ := args[2].(int)
+		holder := args[3].(string)
+
+		set := f.sets[key]
+		if set == nil {
+			set = map[string]int64{}
+			f.sets[key] = set
+		}
+		for member, score := range set {
+			if score < now-ttlMs {
+				delete(set, member)
+			}
+		}
+		if len(set) < n {
+			set[holder] = now
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case semaphoreReleaseScript:
+		set := f.sets[key]
+		if set == nil {
+			return int64(0), nil
+		}
+		if _, ok := set[token]; ok {
+			delete(set, token)
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case semaphoreRenewScript:
+		f.renewCalls++
+		if f.forceRenewFail {
+			return int64(0), nil
+		}
+		now, _ := args[1].(int64)
+		set := f.sets[key]
+		if set == nil {
+			return int64(0), nil
+		}
+		if _, ok := set[token]; ok {
+			set[token] = now
+			return int64(1), nil
+		}
+		return int64(0), nil
+	}
+	return int64(0), nil
+}
+
+// RefreshCalls returns how many times this node has evaled refreshScript.
+func (f *fakeClient) RefreshCalls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.refreshCalls
+}
+
+// RenewCalls returns how many times this node has evaled
+// semaphoreRenewScript.
+func (f *fakeClient) RenewCalls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.renewCalls
+}
+
+// SetForceRefreshFail makes this node report failure on every future
+// refreshScript eval, simulating it no longer holding the lock.
+func (f *fakeClient) SetForceRefreshFail(v bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.forceRefreshFail = v
+}
+
+// SetForceRenewFail makes this node report failure on every future
+// semaphoreRenewScript eval, simulating it no longer holding the slot.
+func (f *fakeClient) SetForceRenewFail(v bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.forceRenewFail = v
+}
+
+func (f *fakeClient) PTTL(ctx context.Context, key string) (time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.values[key]; !ok {
+		return 0, nil
+	}
+	return time.Second, nil
+}
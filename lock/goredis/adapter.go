@@ -0,0 +1,37 @@
+// Package goredis adapts a go-redis UniversalClient (covering *redis.Client,
+// *redis.ClusterClient, and Sentinel-backed clients) to the lock.RedisClient
+// interface expected by lock.New and lock.NewSemaphore.
+package goredis
+
+import (
+	"context"
+	"time"
+
+	"github.com/VarthanV/redlock-go/lock"
+	"github.com/redis/go-redis/v9"
+)
+
+// Adapter wraps a go-redis UniversalClient as a lock.RedisClient.
+type Adapter struct {
+	client redis.UniversalClient
+}
+
+// New wraps client as a lock.RedisClient.
+func New(client redis.UniversalClient) lock.RedisClient {
+	return &Adapter{client: client}
+}
+
+// SetNX implements lock.RedisClient.
+func (a *Adapter) SetNX(ctx context.Context, key, val string, ttl time.Duration) (bool, error) {
+	return a.client.SetNX(ctx, key, val, ttl).Result()
+}
+
+// Eval implements lock.RedisClient.
+func (a *Adapter) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	return a.client.Eval(ctx, script, keys, args...).Result()
+}
+
+// PTTL implements lock.RedisClient.
+func (a *Adapter) PTTL(ctx context.Context, key string) (time.Duration, error) {
+	return a.client.PTTL(ctx, key).Result()
+}
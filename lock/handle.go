@@ -0,0 +1,197 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// refreshScript extends a lock's TTL, but only if the caller still holds it,
+// using the same check-and-set guarantee as releaseScript.
+const refreshScript = `
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+else
+	return 0
+end
+`
+
+// Lock is a handle to a lock acquired across a Redlock quorum. It carries
+// the random token the lock was acquired with, so Release and Refresh can
+// never affect a key some other client owns.
+type Lock struct {
+	key     string
+	token   string
+	clients []RedisClient
+	quorum  int
+
+	requestSem chan struct{}
+
+	deadline time.Time
+
+	// lost and watchdogCancel are only set when the lock was acquired with
+	// WithAutoExtend; both are nil otherwise. watchdogCancel is its own
+	// context.WithCancel derived from context.Background(), not the ctx
+	// passed into Acquire, so the watchdog outlives that single call and
+	// only stops on Release or a failed refresh.
+	lost           chan struct{}
+	watchdogCancel context.CancelFunc
+	stopOnce       sync.Once
+}
+
+// Lost returns a channel that is closed if the auto-extend watchdog fails to
+// renew the lock on quorum before it would expire, signalling that the
+// caller no longer safely holds it. It returns nil if the lock was acquired
+// without WithAutoExtend.
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// watchdog periodically refreshes the lock so it survives for as long as
+// ctx is live, stopping on Release, ctx cancellation, or a failed refresh.
+// ctx is expected to be long-lived (its own context.WithCancel, not the
+// deadline-bound ctx passed into Acquire), since extendInterval/keyValidity
+// are meant to carry the lock across work of unknown duration.
+func (l *Lock) watchdog(ctx context.Context, extendInterval, keyValidity time.Duration) {
+	ticker := time.NewTicker(extendInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refreshCtx, cancel := context.WithTimeout(ctx, extendInterval)
+			err := l.Refresh(refreshCtx, keyValidity)
+			cancel()
+			if err != nil {
+				close(l.lost)
+				return
+			}
+		}
+	}
+}
+
+// stopWatchdog stops the auto-extend goroutine, if one is running. It is
+// safe to call more than once and on locks acquired without WithAutoExtend.
+func (l *Lock) stopWatchdog() {
+	if l.watchdogCancel == nil {
+		return
+	}
+	l.stopOnce.Do(l.watchdogCancel)
+}
+
+// Release releases the lock on every node that still holds it.
+func (l *Lock) Release(ctx context.Context) error {
+	l.stopWatchdog()
+
+	var (
+		releasedCount = atomic.Int32{}
+		done          = make(chan interface{})
+		closeDone     sync.Once
+		wg            sync.WaitGroup
+	)
+
+	for _, c := range l.clients {
+		wg.Add(1)
+		go func(client RedisClient) {
+			defer wg.Done()
+			<-l.requestSem
+			defer func() { l.requestSem <- struct{}{} }()
+			// A result of 0 means this node's key didn't match our token,
+			// i.e. it isn't ours (already expired or never acquired) rather
+			// than a failed release, so it simply doesn't count towards quorum.
+			result, err := client.Eval(ctx, releaseScript, []string{l.key}, l.token)
+			if err == nil && toInt64(result) == 1 {
+				if releasedCount.Add(1) == int32(l.quorum) {
+					closeDone.Do(func() { close(done) })
+				}
+			}
+		}(c)
+	}
+
+	go func() {
+		wg.Wait()
+		closeDone.Do(func() { close(done) })
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		if releasedCount.Load() == int32(l.quorum) {
+			return nil
+		}
+		return ErrUnableToReleaseLock
+	}
+}
+
+// Refresh extends the lock's TTL to newTTL on every node that still holds
+// it, re-establishing quorum the same way Acquire does.
+func (l *Lock) Refresh(ctx context.Context, newTTL time.Duration) error {
+	var (
+		refreshedCount = atomic.Int32{}
+		done           = make(chan interface{})
+		closeDone      sync.Once
+		wg             sync.WaitGroup
+	)
+
+	for _, c := range l.clients {
+		wg.Add(1)
+		go func(client RedisClient) {
+			defer wg.Done()
+			<-l.requestSem
+			defer func() { l.requestSem <- struct{}{} }()
+			result, err := client.Eval(ctx, refreshScript, []string{l.key}, l.token, newTTL.Milliseconds())
+			if err == nil && toInt64(result) == 1 {
+				if refreshedCount.Add(1) == int32(l.quorum) {
+					closeDone.Do(func() { close(done) })
+				}
+			}
+		}(c)
+	}
+
+	go func() {
+		wg.Wait()
+		closeDone.Do(func() { close(done) })
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		if refreshedCount.Load() == int32(l.quorum) {
+			l.deadline = time.Now().Add(newTTL)
+			return nil
+		}
+		return ErrUnableToRefreshLock
+	}
+}
+
+// TTL returns the minimum remaining PTTL across the nodes where the lock's
+// key exists. PTTL does not compare the stored value with this lock's token,
+// so a node where the key now belongs to another client is counted as well,
+// and ErrLockNotHeld is returned only when no node has the key at all.
+func (l *Lock) TTL(ctx context.Context) (time.Duration, error) {
+	var (
+		min   time.Duration
+		found bool
+	)
+
+	for _, c := range l.clients {
+		pttl, err := c.PTTL(ctx, l.key)
+		if err != nil || pttl <= 0 {
+			continue
+		}
+		if !found || pttl < min {
+			min = pttl
+			found = true
+		}
+	}
+
+	if !found {
+		return 0, ErrLockNotHeld
+	}
+	return min, nil
+}
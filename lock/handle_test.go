@@ -0,0 +1,73 @@
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithAutoExtendKeepsLockAliveAcrossLockDuration(t *testing.T) {
+	fakes := newFakeClients(3)
+	lockDuration := 40 * time.Millisecond
+	l := New(asRedisClients(fakes), lockDuration, WithAutoExtend(10*time.Millisecond, lockDuration))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	lock, err := l.Acquire(ctx, "foo", AcquireOptions{})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	defer lock.Release(context.Background())
+
+	// Sleep well past the original lockDuration; without the watchdog
+	// refreshing it the key would have expired on a real Redis node.
+	time.Sleep(3 * lockDuration)
+
+	select {
+	case <-lock.Lost():
+		t.Fatal("Lost() closed, watchdog failed to keep the lock alive")
+	default:
+	}
+
+	quorumRefreshed := 0
+	for _, f := range fakes {
+		if f.RefreshCalls() > 0 {
+			quorumRefreshed++
+		}
+	}
+	if quorumRefreshed < 2 {
+		t.Fatalf("only %d/3 nodes saw a refresh call, want at least quorum (2)", quorumRefreshed)
+	}
+
+	if err := lock.Release(context.Background()); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+}
+
+func TestWithAutoExtendLostWhenRefreshFails(t *testing.T) {
+	fakes := newFakeClients(3)
+	lockDuration := 40 * time.Millisecond
+	l := New(asRedisClients(fakes), lockDuration, WithAutoExtend(10*time.Millisecond, lockDuration))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	lock, err := l.Acquire(ctx, "foo", AcquireOptions{})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	defer lock.Release(context.Background())
+
+	// Make a majority of nodes start refusing to refresh, simulating the
+	// caller losing ownership of the lock out from under it.
+	for _, f := range fakes[:2] {
+		f.SetForceRefreshFail(true)
+	}
+
+	select {
+	case <-lock.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("Lost() never closed after quorum stopped refreshing")
+	}
+}
@@ -2,7 +2,10 @@ package lock
 
 import "context"
 
+// ILock is implemented by distributed lock managers. Acquire blocks until
+// quorum is reached, its retries are used up, or ctx is done, returning a
+// handle scoped to that single acquisition rather than requiring the caller
+// to pass the key back in.
 type ILock interface {
-	Acquire(ctx context.Context, key string) error
-	Release(ctx context.Context, key string) error
+	Acquire(ctx context.Context, key string, opts AcquireOptions) (*Lock, error)
 }
@@ -0,0 +1,19 @@
+package lock
+
+import "time"
+
+// Option configures a redLock created via New.
+type Option func(*redLock)
+
+// WithAutoExtend enables a background watchdog that periodically re-issues
+// the lock's TTL so callers can hold it across work of unknown duration
+// without picking an oversized initial lockDuration. The watchdog refreshes
+// every extendInterval, resetting the key's validity to keyValidity each
+// time, and stops on Release or a failed refresh (surfaced to the caller via
+// (*Lock).Lost). Cancelling the ctx passed to Acquire does not stop it.
+func WithAutoExtend(extendInterval, keyValidity time.Duration) Option {
+	return func(r *redLock) {
+		r.autoExtendInterval = extendInterval
+		r.autoExtendValidity = keyValidity
+	}
+}
@@ -2,171 +2,187 @@ package lock
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"sync"
-	"sync/atomic"
 	"time"
 
-	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 )
 
+// releaseScript is the standard Redlock check-and-delete script. It only
+// deletes the key if the value still matches the token the caller acquired
+// it with, so a client can never release a lock it doesn't own.
+const releaseScript = `
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+else
+	return 0
+end
+`
+
 type redLock struct {
-	clients      []*redis.Client
+	clients      []RedisClient
 	requestSem   chan struct{}
 	lockDuration time.Duration
+
+	autoExtendInterval time.Duration
+	autoExtendValidity time.Duration
 }
 
-func New(clients []*redis.Client, lockDuration time.Duration) ILock {
+func New(clients []RedisClient, lockDuration time.Duration, opts ...Option) ILock {
 	maxConcurrencyAllowed := 10
 	l := &redLock{
 		clients:      clients,
-		requestSem:   make(chan struct{}),
+		requestSem:   make(chan struct{}, maxConcurrencyAllowed),
 		lockDuration: lockDuration,
 	}
 
+	for _, opt := range opts {
+		opt(l)
+	}
+
 	for i := 0; i < maxConcurrencyAllowed; i++ {
 		l.requestSem <- struct{}{}
 	}
 	return l
 }
 
-type acquireActionOutcome struct {
-	acquired bool
+// newToken returns a random, hex-encoded value that uniquely identifies a
+// single lock acquisition so Release can never remove a key it didn't set.
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
 
-// Acquire implements ILock.
-func (r *redLock) Acquire(ctx context.Context, key string) error {
-	var (
-		acquiredCount       = atomic.Int32{}
-		quorum              = len(r.clients)/2 + 1
-		acquireActionStream = make(chan acquireActionOutcome)
-		done                = make(chan interface{})
-		wg                  sync.WaitGroup
-	)
-
-	defer close(acquireActionStream)
-
+// Acquire implements ILock. It retries up to opts.RetryCount times,
+// waiting between attempts as directed by opts.RetryStrategy, until quorum
+// is reached with enough validity left on the clock or ctx is done.
+func (r *redLock) Acquire(ctx context.Context, key string, opts AcquireOptions) (*Lock, error) {
 	_, ok := ctx.Deadline()
 	if !ok {
 		logrus.Error(ErrContextWithDeadlineNeeded.Error())
-		return ErrContextWithDeadlineNeeded
+		return nil, ErrContextWithDeadlineNeeded
 	}
-	go func() {
-		for val := range acquireActionStream {
-			if val.acquired {
-				newVal := acquiredCount.Add(1)
-				if newVal == int32(quorum) {
-					close(done)
-					return
-				}
-			}
-		}
-	}()
-
-	for _, c := range r.clients {
-		wg.Add(1)
-		// Practically finite number of redis clusters which is acceptable count to make concurrent requests
-		// will only will be there, just adding semaphore to gracefully handle the situation
-		go func(c *redis.Client) {
-			defer wg.Done()
-			<-r.requestSem
-			// After the sem is acquired release it after the operation is done
-			if acquiredCount.Load() == int32(quorum) {
-				close(done)
-				return
-			}
-			acquiredResult := c.SetNX(ctx, key, "1", r.lockDuration)
-			acquireActionStream <- acquireActionOutcome{
-				acquired: acquiredResult.Val(),
-			}
-			r.requestSem <- struct{}{}
-		}(c)
 
+	strategy := opts.RetryStrategy
+	if strategy == nil {
+		strategy = LinearBackoff{}
 	}
 
-	go func() {
-		wg.Wait()
-		select {
-		case <-ctx.Done():
-			return
-		case <-done:
-			return
-		default:
-			close(done)
+	var prevDelay time.Duration
+	for attempt := 0; ; attempt++ {
+		l, err := r.attemptAcquire(ctx, key)
+		if err == nil {
+			return l, nil
+		}
+		if attempt >= opts.RetryCount {
+			return nil, err
 		}
-	}()
 
-	for {
+		prevDelay = strategy.NextDelay(attempt+1, opts.RetryDelay, prevDelay)
+		timer := time.NewTimer(prevDelay)
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
-		case <-done:
-			if acquiredCount.Load() == int32(quorum) {
-				return nil
-			}
-			return ErrUnableToAcquireLock
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
 		}
-
 	}
 }
 
-// Release implements ILock.
-func (r *redLock) Release(ctx context.Context, key string) error {
+// attemptAcquire makes a single SetNX pass across the quorum and, per the
+// Redlock paper, accounts for clock drift and the time spent reaching
+// quorum: validity := lockDuration - elapsed - drift. If that leaves no
+// usable time, the partially acquired lock is released rather than handed
+// back to the caller already expired.
+func (r *redLock) attemptAcquire(ctx context.Context, key string) (*Lock, error) {
 	var (
-		releasedCount = atomic.Int32{}
-		quorum        = len(r.clients)/2 + 1
-		done          = make(chan interface{})
-		wg            sync.WaitGroup
+		quorum          = len(r.clients)/2 + 1
+		wg              sync.WaitGroup
+		acquiredMu      sync.Mutex
+		acquiredClients []RedisClient
 	)
 
-	_, ok := ctx.Deadline()
-	if !ok {
-		logrus.Error(ErrContextWithDeadlineNeeded.Error())
-		return ErrContextWithDeadlineNeeded
+	token, err := newToken()
+	if err != nil {
+		return nil, err
 	}
 
+	start := time.Now()
+
 	for _, c := range r.clients {
 		wg.Add(1)
-		go func(client *redis.Client) {
+		// Practically finite number of redis clusters which is acceptable count to make concurrent requests
+		// will only will be there, just adding semaphore to gracefully handle the situation
+		go func(c RedisClient) {
 			defer wg.Done()
 			<-r.requestSem
-			// After the sem is acquired release it after the operation is done
-			if releasedCount.Load() == int32(quorum) {
-				close(done)
-				return
-			}
-			result := client.Del(ctx, key)
-			if result.Val() == 1 {
-				if releasedCount.Add(1) == int32(quorum) {
-					close(done)
-					return
-				}
+			acquired, _ := c.SetNX(ctx, key, token, r.lockDuration)
+			if acquired {
+				acquiredMu.Lock()
+				acquiredClients = append(acquiredClients, c)
+				acquiredMu.Unlock()
 			}
 			r.requestSem <- struct{}{}
 		}(c)
+
 	}
 
+	// Wait for every client to report in, not just until quorum is reached:
+	// returning earlier would let a slower goroutine still be running after
+	// this function's caller has moved on, which used to race a since-closed
+	// result channel.
+	allDone := make(chan struct{})
 	go func() {
 		wg.Wait()
-		select {
-		case <-ctx.Done():
-			return
-		case <-done:
-			return
-		default:
-			close(done)
-		}
+		close(allDone)
 	}()
 
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-done:
-			if releasedCount.Load() == int32(quorum) {
-				return nil
-			}
-			return ErrUnableToReleaseLock
-		}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-allDone:
+	}
+
+	if len(acquiredClients) < quorum {
+		return nil, ErrUnableToAcquireLock
+	}
+
+	// drift, as specified by the Redlock paper: a small amount per the
+	// configured TTL plus a fixed allowance for network/clock imprecision.
+	drift := time.Duration(float64(r.lockDuration)*0.01) + 2*time.Millisecond
+	validity := r.lockDuration - time.Since(start) - drift
+	if validity <= 0 {
+		r.releasePartial(ctx, key, token, acquiredClients)
+		return nil, ErrUnableToAcquireLock
+	}
+
+	l := &Lock{
+		key:        key,
+		token:      token,
+		clients:    r.clients,
+		quorum:     quorum,
+		requestSem: r.requestSem,
+		deadline:   start.Add(validity),
+	}
+	if r.autoExtendInterval > 0 {
+		watchdogCtx, cancel := context.WithCancel(context.Background())
+		l.lost = make(chan struct{})
+		l.watchdogCancel = cancel
+		go l.watchdog(watchdogCtx, r.autoExtendInterval, r.autoExtendValidity)
+	}
+	return l, nil
+}
+
+// releasePartial best-effort releases a lock this client partially
+// acquired but is abandoning (e.g. because drift accounting left it no
+// usable validity), so other clients aren't blocked by it.
+func (r *redLock) releasePartial(ctx context.Context, key, token string, clients []RedisClient) {
+	for _, c := range clients {
+		c.Eval(ctx, releaseScript, []string{key}, token)
 	}
 }
@@ -0,0 +1,155 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newClients(n int) []RedisClient {
+	clients := make([]RedisClient, n)
+	for i := range clients {
+		clients[i] = newFakeClient()
+	}
+	return clients
+}
+
+// newFakeClients is like newClients but keeps the concrete *fakeClient type,
+// for tests that need to inspect call counts or force failures.
+func newFakeClients(n int) []*fakeClient {
+	clients := make([]*fakeClient, n)
+	for i := range clients {
+		clients[i] = newFakeClient()
+	}
+	return clients
+}
+
+func asRedisClients(fakes []*fakeClient) []RedisClient {
+	clients := make([]RedisClient, len(fakes))
+	for i, f := range fakes {
+		clients[i] = f
+	}
+	return clients
+}
+
+func TestAcquireReachesQuorum(t *testing.T) {
+	l := New(newClients(3), time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	lock, err := l.Acquire(ctx, "foo", AcquireOptions{})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+}
+
+func TestAcquireFailsWithoutQuorum(t *testing.T) {
+	held := newFakeClient()
+	held.values["foo"] = "someone-else-already-owns-this"
+	clients := []RedisClient{held, held, newFakeClient()}
+	l := New(clients, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if _, err := l.Acquire(ctx, "foo", AcquireOptions{}); !errors.Is(err, ErrUnableToAcquireLock) {
+		t.Fatalf("Acquire() error = %v, want %v", err, ErrUnableToAcquireLock)
+	}
+}
+
+func TestAcquireRequiresDeadline(t *testing.T) {
+	l := New(newClients(3), time.Second)
+
+	if _, err := l.Acquire(context.Background(), "foo", AcquireOptions{}); !errors.Is(err, ErrContextWithDeadlineNeeded) {
+		t.Fatalf("Acquire() error = %v, want %v", err, ErrContextWithDeadlineNeeded)
+	}
+}
+
+// TestAcquireWaitsForStragglers is a regression test for a race where
+// attemptAcquire used to return as soon as quorum was reached, while
+// slower client goroutines were still running and could panic trying to
+// send on an already-closed result channel. Acquire now waits for the
+// straggler to report in as well, and must succeed without that straggler
+// ever causing a crash.
+func TestAcquireWaitsForStragglers(t *testing.T) {
+	slow := newFakeClient()
+	slow.delay = 50 * time.Millisecond
+	clients := []RedisClient{newFakeClient(), newFakeClient(), newFakeClient(), newFakeClient(), slow}
+	l := New(clients, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	lock, err := l.Acquire(ctx, "foo", AcquireOptions{})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	// Sleep past the straggler's delay; no goroutine from Acquire may still
+	// be running to panic the test process.
+	time.Sleep(100 * time.Millisecond)
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+}
+
+// TestAcquireReleaseLoopDoesNotLeakSemaphore is a regression test for a bug
+// where Release returned without ever sending back on requestSem when it
+// took the "quorum already reached" fast path or the branch that happened
+// to hit quorum exactly, permanently draining a slot shared with Acquire.
+// A healthy loop of Acquire+Release used to deadlock on the 6th iteration
+// once all 10 slots were gone.
+func TestAcquireReleaseLoopDoesNotLeakSemaphore(t *testing.T) {
+	l := New(newClients(3), time.Second)
+
+	for i := 0; i < 20; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		lock, err := l.Acquire(ctx, "foo", AcquireOptions{})
+		if err != nil {
+			cancel()
+			t.Fatalf("iteration %d: Acquire() error = %v", i, err)
+		}
+		if err := lock.Release(ctx); err != nil {
+			cancel()
+			t.Fatalf("iteration %d: Release() error = %v", i, err)
+		}
+		cancel()
+	}
+}
+
+func TestAcquireRetriesUntilSuccess(t *testing.T) {
+	// Two of three nodes are held, so no attempt reaches quorum until both
+	// are freed.
+	heldA, heldB := newFakeClient(), newFakeClient()
+	heldA.values["foo"] = "someone-else-already-owns-this"
+	heldB.values["foo"] = "someone-else-already-owns-this"
+	clients := []RedisClient{heldA, heldB, newFakeClient()}
+	l := New(clients, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		for _, held := range []*fakeClient{heldA, heldB} {
+			held.mu.Lock()
+			delete(held.values, "foo")
+			held.mu.Unlock()
+		}
+	}()
+
+	lock, err := l.Acquire(ctx, "foo", AcquireOptions{
+		RetryCount: 5,
+		RetryDelay: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package lock
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RetryStrategy decides how long Acquire should wait before the next retry
+// attempt after failing to reach quorum.
+type RetryStrategy interface {
+	// NextDelay returns how long to wait before the given attempt (1-indexed).
+	// baseDelay is AcquireOptions.RetryDelay, and prevDelay is the delay
+	// returned for the previous attempt (0 before the first retry).
+	NextDelay(attempt int, baseDelay, prevDelay time.Duration) time.Duration
+}
+
+// LinearBackoff retries after the same fixed delay every time.
+type LinearBackoff struct{}
+
+func (LinearBackoff) NextDelay(attempt int, baseDelay, prevDelay time.Duration) time.Duration {
+	return baseDelay
+}
+
+// ExponentialBackoff doubles the delay on every attempt, up to Cap.
+type ExponentialBackoff struct {
+	Cap time.Duration
+}
+
+func (e ExponentialBackoff) NextDelay(attempt int, baseDelay, prevDelay time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := baseDelay << (attempt - 1)
+	if delay <= 0 || delay > e.Cap {
+		return e.Cap
+	}
+	return delay
+}
+
+// ExponentialBackoffWithJitter implements the "decorrelated jitter" backoff:
+// sleep = min(cap, rand(base, prev*3)). It spreads out retries from
+// competing clients far better than plain exponential backoff.
+type ExponentialBackoffWithJitter struct {
+	Cap time.Duration
+}
+
+func (e ExponentialBackoffWithJitter) NextDelay(attempt int, baseDelay, prevDelay time.Duration) time.Duration {
+	if prevDelay <= 0 {
+		prevDelay = baseDelay
+	}
+	hi := prevDelay * 3
+	if hi <= baseDelay {
+		hi = baseDelay + 1
+	}
+	delay := baseDelay + time.Duration(rand.Int63n(int64(hi-baseDelay)))
+	if delay > e.Cap {
+		delay = e.Cap
+	}
+	return delay
+}
+
+// AcquireOptions configures retry behaviour for a single Acquire call.
+type AcquireOptions struct {
+	// RetryCount is how many additional attempts to make after the first
+	// one fails to reach quorum. Zero means Acquire is single-shot.
+	RetryCount int
+	// RetryDelay is the base delay passed to RetryStrategy.NextDelay.
+	RetryDelay time.Duration
+	// RetryStrategy picks the delay before each retry. Defaults to
+	// LinearBackoff (a fixed RetryDelay) if nil.
+	RetryStrategy RetryStrategy
+}
@@ -0,0 +1,46 @@
+package lock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLinearBackoff(t *testing.T) {
+	l := LinearBackoff{}
+	base := 100 * time.Millisecond
+
+	for attempt := 1; attempt <= 5; attempt++ {
+		if got := l.NextDelay(attempt, base, 3*base); got != base {
+			t.Fatalf("attempt %d: NextDelay() = %v, want %v", attempt, got, base)
+		}
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	base := 100 * time.Millisecond
+	cap := 900 * time.Millisecond
+	e := ExponentialBackoff{Cap: cap}
+
+	want := []time.Duration{base, 2 * base, 4 * base, 8 * base, cap}
+	for i, w := range want {
+		attempt := i + 1
+		if got := e.NextDelay(attempt, base, 0); got != w {
+			t.Fatalf("attempt %d: NextDelay() = %v, want %v", attempt, got, w)
+		}
+	}
+}
+
+func TestExponentialBackoffWithJitter(t *testing.T) {
+	base := 100 * time.Millisecond
+	cap := time.Second
+	e := ExponentialBackoffWithJitter{Cap: cap}
+
+	prev := time.Duration(0)
+	for attempt := 1; attempt <= 20; attempt++ {
+		got := e.NextDelay(attempt, base, prev)
+		if got < base || got > cap {
+			t.Fatalf("attempt %d: NextDelay() = %v, want in [%v, %v]", attempt, got, base, cap)
+		}
+		prev = got
+	}
+}
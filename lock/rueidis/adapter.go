@@ -0,0 +1,62 @@
+// Package rueidis adapts a rueidis.Client (which offers client-side caching
+// and auto-pipelining, reducing per-Acquire latency against many nodes) to
+// the lock.RedisClient interface expected by lock.New and lock.NewSemaphore.
+// It lives in its own subpackage so depending on redlock-go doesn't pull in
+// rueidis unless this adapter is imported.
+package rueidis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/VarthanV/redlock-go/lock"
+	"github.com/redis/rueidis"
+)
+
+// Adapter wraps a rueidis.Client as a lock.RedisClient.
+type Adapter struct {
+	client rueidis.Client
+}
+
+// New wraps client as a lock.RedisClient.
+func New(client rueidis.Client) lock.RedisClient {
+	return &Adapter{client: client}
+}
+
+// SetNX implements lock.RedisClient.
+func (a *Adapter) SetNX(ctx context.Context, key, val string, ttl time.Duration) (bool, error) {
+	cmd := a.client.B().Set().Key(key).Value(val).Nx().Px(ttl).Build()
+	resp := a.client.Do(ctx, cmd)
+	if err := resp.Error(); err != nil {
+		if rueidis.IsRedisNil(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Eval implements lock.RedisClient.
+func (a *Adapter) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	strArgs := make([]string, len(args))
+	for i, arg := range args {
+		strArgs[i] = fmt.Sprint(arg)
+	}
+	cmd := a.client.B().Eval().Script(script).Numkeys(int64(len(keys))).Key(keys...).Arg(strArgs...).Build()
+	resp := a.client.Do(ctx, cmd)
+	if err := resp.Error(); err != nil {
+		return nil, err
+	}
+	return resp.ToInt64()
+}
+
+// PTTL implements lock.RedisClient.
+func (a *Adapter) PTTL(ctx context.Context, key string) (time.Duration, error) {
+	cmd := a.client.B().Pttl().Key(key).Build()
+	ms, err := a.client.Do(ctx, cmd).ToInt64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
@@ -0,0 +1,345 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// semaphoreAcquireScript represents each semaphore as a sorted set keyed by
+// holder token with the acquisition time as score. It first garbage
+// collects holders whose TTL has expired, then admits a new holder only if
+// doing so keeps the set at or under n members.
+const semaphoreAcquireScript = `
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local ttlMs = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local token = ARGV[4]
+
+redis.call("zremrangebyscore", key, "-inf", now - ttlMs)
+if redis.call("zcard", key) < n then
+	redis.call("zadd", key, now, token)
+	return 1
+end
+return 0
+`
+
+// semaphoreReleaseScript removes a holder's own slot. The member is the
+// holder's unique token, so this can never remove a slot held by someone
+// else.
+const semaphoreReleaseScript = `
+return redis.call("zrem", KEYS[1], ARGV[1])
+`
+
+// semaphoreRenewScript extends a holder's score only if its slot is still
+// present, the sorted-set equivalent of the mutex's CAS refresh: a holder
+// that already lost its slot is never resurrected.
+const semaphoreRenewScript = `
+if redis.call("zscore", KEYS[1], ARGV[1]) then
+	redis.call("zadd", KEYS[1], ARGV[2], ARGV[1])
+	return 1
+else
+	return 0
+end
+`
+
+// ISemaphore is a distributed, N-holder semaphore across a Redlock quorum.
+type ISemaphore interface {
+	Acquire(ctx context.Context, key string, n int, opts AcquireOptions) (ReleaseFunc, error)
+}
+
+// ReleaseFunc releases a lock or semaphore slot previously acquired.
+type ReleaseFunc func(ctx context.Context) error
+
+type redSemaphore struct {
+	clients      []RedisClient
+	requestSem   chan struct{}
+	lockDuration time.Duration
+
+	autoExtendInterval time.Duration
+	autoExtendValidity time.Duration
+}
+
+// NewSemaphore creates an ISemaphore backed by the same quorum-of-Redis
+// setup as New, accepting the same Options (e.g. WithAutoExtend). With
+// WithAutoExtend, only extendInterval applies: a renewed slot stays valid for
+// lockDuration from each renewal, whatever keyValidity is.
+func NewSemaphore(clients []RedisClient, lockDuration time.Duration, opts ...Option) ISemaphore {
+	maxConcurrencyAllowed := 10
+	s := &redSemaphore{
+		clients:      clients,
+		requestSem:   make(chan struct{}, maxConcurrencyAllowed),
+		lockDuration: lockDuration,
+	}
+
+	r := &redLock{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	s.autoExtendInterval = r.autoExtendInterval
+	s.autoExtendValidity = r.autoExtendValidity
+
+	for i := 0; i < maxConcurrencyAllowed; i++ {
+		s.requestSem <- struct{}{}
+	}
+	return s
+}
+
+// semaphoreHolder is the handle kept for an acquired semaphore slot; its
+// Release method is what Acquire hands back as a ReleaseFunc.
+type semaphoreHolder struct {
+	key     string
+	token   string
+	clients []RedisClient
+	quorum  int
+	ttl     time.Duration
+
+	requestSem chan struct{}
+
+	// lost and watchdogCancel are only set when the semaphore was acquired
+	// with WithAutoExtend; both are nil otherwise. watchdogCancel is its
+	// own context.WithCancel derived from context.Background(), not the
+	// ctx passed into Acquire, so the watchdog outlives that single call
+	// and only stops on Release or a failed renew.
+	lost           chan struct{}
+	watchdogCancel context.CancelFunc
+	stopOnce       sync.Once
+}
+
+// Acquire implements ISemaphore. It retries up to opts.RetryCount times,
+// waiting between attempts as directed by opts.RetryStrategy, the same way
+// redLock.Acquire does.
+func (r *redSemaphore) Acquire(ctx context.Context, key string, n int, opts AcquireOptions) (ReleaseFunc, error) {
+	_, ok := ctx.Deadline()
+	if !ok {
+		logrus.Error(ErrContextWithDeadlineNeeded.Error())
+		return nil, ErrContextWithDeadlineNeeded
+	}
+
+	strategy := opts.RetryStrategy
+	if strategy == nil {
+		strategy = LinearBackoff{}
+	}
+
+	var prevDelay time.Duration
+	for attempt := 0; ; attempt++ {
+		h, err := r.attemptAcquire(ctx, key, n)
+		if err == nil {
+			if r.autoExtendInterval > 0 {
+				watchdogCtx, cancel := context.WithCancel(context.Background())
+				h.lost = make(chan struct{})
+				h.watchdogCancel = cancel
+				go h.watchdog(watchdogCtx, r.autoExtendInterval, r.autoExtendValidity)
+			}
+			return h.Release, nil
+		}
+		if attempt >= opts.RetryCount {
+			return nil, err
+		}
+
+		prevDelay = strategy.NextDelay(attempt+1, opts.RetryDelay, prevDelay)
+		timer := time.NewTimer(prevDelay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (r *redSemaphore) attemptAcquire(ctx context.Context, key string, n int) (*semaphoreHolder, error) {
+	var (
+		quorum     = len(r.clients)/2 + 1
+		wg         sync.WaitGroup
+		acquiredMu sync.Mutex
+		acquiredOK int
+	)
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+
+	for _, c := range r.clients {
+		wg.Add(1)
+		go func(c RedisClient) {
+			defer wg.Done()
+			<-r.requestSem
+			result, err := c.Eval(ctx, semaphoreAcquireScript, []string{key}, now, r.lockDuration.Milliseconds(), n, token)
+			if err == nil && toInt64(result) == 1 {
+				acquiredMu.Lock()
+				acquiredOK++
+				acquiredMu.Unlock()
+			}
+			r.requestSem <- struct{}{}
+		}(c)
+	}
+
+	// Wait for every client to report in, not just until quorum is reached:
+	// returning earlier would let a slower goroutine still be running after
+	// this function's caller has moved on, which used to race a since-closed
+	// result channel.
+	allDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(allDone)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-allDone:
+	}
+
+	if acquiredOK < quorum {
+		return nil, ErrUnableToAcquireLock
+	}
+
+	return &semaphoreHolder{
+		key:        key,
+		token:      token,
+		clients:    r.clients,
+		quorum:     quorum,
+		ttl:        r.lockDuration,
+		requestSem: r.requestSem,
+	}, nil
+}
+
+// Release removes this holder's slot from the semaphore on every node that
+// still has it.
+func (h *semaphoreHolder) Release(ctx context.Context) error {
+	h.stopWatchdog()
+
+	var (
+		releasedCount = atomic.Int32{}
+		done          = make(chan interface{})
+		closeDone     sync.Once
+		wg            sync.WaitGroup
+	)
+
+	for _, c := range h.clients {
+		wg.Add(1)
+		go func(client RedisClient) {
+			defer wg.Done()
+			<-h.requestSem
+			defer func() { h.requestSem <- struct{}{} }()
+			result, err := client.Eval(ctx, semaphoreReleaseScript, []string{h.key}, h.token)
+			if err == nil && toInt64(result) == 1 {
+				if releasedCount.Add(1) == int32(h.quorum) {
+					closeDone.Do(func() { close(done) })
+				}
+			}
+		}(c)
+	}
+
+	go func() {
+		wg.Wait()
+		closeDone.Do(func() { close(done) })
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		if releasedCount.Load() == int32(h.quorum) {
+			return nil
+		}
+		return ErrUnableToReleaseLock
+	}
+}
+
+// renew refreshes this holder's score so it isn't garbage collected by the
+// next Acquire's expiry sweep.
+func (h *semaphoreHolder) renew(ctx context.Context) error {
+	var (
+		renewedCount = atomic.Int32{}
+		done         = make(chan interface{})
+		closeDone    sync.Once
+		wg           sync.WaitGroup
+	)
+
+	now := time.Now().UnixMilli()
+
+	for _, c := range h.clients {
+		wg.Add(1)
+		go func(client RedisClient) {
+			defer wg.Done()
+			<-h.requestSem
+			defer func() { h.requestSem <- struct{}{} }()
+			if renewedCount.Load() == int32(h.quorum) {
+				closeDone.Do(func() { close(done) })
+				return
+			}
+			result, err := client.Eval(ctx, semaphoreRenewScript, []string{h.key}, h.token, now)
+			if err == nil && toInt64(result) == 1 {
+				if renewedCount.Add(1) == int32(h.quorum) {
+					closeDone.Do(func() { close(done) })
+				}
+			}
+		}(c)
+	}
+
+	go func() {
+		wg.Wait()
+		closeDone.Do(func() { close(done) })
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		if renewedCount.Load() == int32(h.quorum) {
+			return nil
+		}
+		return ErrUnableToRefreshLock
+	}
+}
+
+// Lost returns a channel that is closed if the auto-extend watchdog fails
+// to renew this holder's slot on quorum, signalling the caller no longer
+// safely holds it. It returns nil if the semaphore was acquired without
+// WithAutoExtend.
+func (h *semaphoreHolder) Lost() <-chan struct{} {
+	return h.lost
+}
+
+// watchdog periodically renews the holder's slot so it survives for as long
+// as ctx is live. ctx is expected to be long-lived (its own
+// context.WithCancel, not the deadline-bound ctx passed into Acquire), since
+// the watchdog carries the slot across work of unknown duration.
+// keyValidity is not used: each renewal resets the slot's score to the
+// current time, and Acquire's expiry sweep measures that score against the
+// semaphore's lockDuration.
+func (h *semaphoreHolder) watchdog(ctx context.Context, extendInterval, keyValidity time.Duration) {
+	ticker := time.NewTicker(extendInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			renewCtx, cancel := context.WithTimeout(ctx, extendInterval)
+			err := h.renew(renewCtx)
+			cancel()
+			if err != nil {
+				close(h.lost)
+				return
+			}
+		}
+	}
+}
+
+func (h *semaphoreHolder) stopWatchdog() {
+	if h.watchdogCancel == nil {
+		return
+	}
+	h.stopOnce.Do(h.watchdogCancel)
+}
@@ -0,0 +1,190 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSemaphoreAcquireReachesQuorum(t *testing.T) {
+	s := NewSemaphore(newClients(3), time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	release, err := s.Acquire(ctx, "foo", 2, AcquireOptions{})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if err := release(ctx); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+}
+
+func TestSemaphoreAcquireFailsWhenFull(t *testing.T) {
+	clients := newClients(3)
+	s := NewSemaphore(clients, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Fill the single slot first.
+	release, err := s.Acquire(ctx, "foo", 1, AcquireOptions{})
+	if err != nil {
+		t.Fatalf("first Acquire() error = %v", err)
+	}
+	defer release(ctx)
+
+	if _, err := s.Acquire(ctx, "foo", 1, AcquireOptions{}); !errors.Is(err, ErrUnableToAcquireLock) {
+		t.Fatalf("second Acquire() error = %v, want %v", err, ErrUnableToAcquireLock)
+	}
+}
+
+func TestSemaphoreAcquireRetriesUntilSuccess(t *testing.T) {
+	clients := newClients(3)
+	s := NewSemaphore(clients, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	release, err := s.Acquire(ctx, "foo", 1, AcquireOptions{})
+	if err != nil {
+		t.Fatalf("first Acquire() error = %v", err)
+	}
+
+	go func() {
+		time.Sleep(15 * time.Millisecond)
+		release(context.Background())
+	}()
+
+	release2, err := s.Acquire(ctx, "foo", 1, AcquireOptions{
+		RetryCount: 20,
+		RetryDelay: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("retried Acquire() error = %v", err)
+	}
+	if err := release2(ctx); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+}
+
+// TestSemaphoreAcquireReleaseLoopDoesNotLeakSemaphore is a regression test
+// for a requestSem leak in semaphoreHolder.Release: every early-return path
+// skipped sending back on requestSem, so a healthy Acquire+Release loop
+// eventually drained the shared pool and deadlocked later Acquires.
+func TestSemaphoreAcquireReleaseLoopDoesNotLeakSemaphore(t *testing.T) {
+	s := NewSemaphore(newClients(3), time.Second)
+
+	for i := 0; i < 20; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		release, err := s.Acquire(ctx, "foo", 2, AcquireOptions{})
+		if err != nil {
+			cancel()
+			t.Fatalf("iteration %d: Acquire() error = %v", i, err)
+		}
+		if err := release(ctx); err != nil {
+			cancel()
+			t.Fatalf("iteration %d: Release() error = %v", i, err)
+		}
+		cancel()
+	}
+}
+
+func TestSemaphoreWithAutoExtendKeepsSlotAliveAcrossTTL(t *testing.T) {
+	fakes := newFakeClients(3)
+	ttl := 40 * time.Millisecond
+	s := NewSemaphore(asRedisClients(fakes), ttl, WithAutoExtend(10*time.Millisecond, ttl))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	release, err := s.Acquire(ctx, "foo", 2, AcquireOptions{})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	defer release(context.Background())
+
+	time.Sleep(3 * ttl)
+
+	quorumRenewed := 0
+	for _, f := range fakes {
+		if f.RenewCalls() > 0 {
+			quorumRenewed++
+		}
+	}
+	if quorumRenewed < 2 {
+		t.Fatalf("only %d/3 nodes saw a renew call, want at least quorum (2)", quorumRenewed)
+	}
+
+	if err := release(context.Background()); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+}
+
+// TestSemaphoreReleaseConcurrentQuorumDoesNotPanic is a regression test for
+// a double-close-of-done panic: once more than quorum nodes release
+// concurrently, one goroutine's success path and another's "already at
+// quorum" fast path used to both close(done) with no guard against it.
+func TestSemaphoreReleaseConcurrentQuorumDoesNotPanic(t *testing.T) {
+	s := NewSemaphore(newClients(3), time.Second)
+
+	for i := 0; i < 20; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		release, err := s.Acquire(ctx, "foo", 5, AcquireOptions{})
+		if err != nil {
+			cancel()
+			t.Fatalf("iteration %d: Acquire() error = %v", i, err)
+		}
+		if err := release(ctx); err != nil {
+			cancel()
+			t.Fatalf("iteration %d: Release() error = %v", i, err)
+		}
+		cancel()
+	}
+}
+
+// TestSemaphoreHolderWatchdogLostWhenRenewFails drives semaphoreHolder's
+// watchdog directly, since ISemaphore.Acquire only hands callers a
+// ReleaseFunc and has no way to expose Lost() through the public API yet.
+// It mirrors what redSemaphore.Acquire wires up internally when
+// WithAutoExtend is set.
+func TestSemaphoreHolderWatchdogLostWhenRenewFails(t *testing.T) {
+	fakes := newFakeClients(3)
+	ttl := 40 * time.Millisecond
+	extendInterval := 10 * time.Millisecond
+
+	r := &redSemaphore{
+		clients:      asRedisClients(fakes),
+		requestSem:   make(chan struct{}, 10),
+		lockDuration: ttl,
+	}
+	for i := 0; i < 10; i++ {
+		r.requestSem <- struct{}{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	h, err := r.attemptAcquire(ctx, "foo", 2)
+	if err != nil {
+		t.Fatalf("attemptAcquire() error = %v", err)
+	}
+	defer h.Release(context.Background())
+
+	watchdogCtx, wcancel := context.WithCancel(context.Background())
+	h.lost = make(chan struct{})
+	h.watchdogCancel = wcancel
+	go h.watchdog(watchdogCtx, extendInterval, ttl)
+
+	for _, f := range fakes[:2] {
+		f.SetForceRenewFail(true)
+	}
+
+	select {
+	case <-h.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("Lost() never closed after quorum stopped renewing")
+	}
+}